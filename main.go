@@ -20,12 +20,17 @@ import (
 	"github.com/cu-library/overridefromenv"
 
 	"github.com/cu-library/almatoolkit/api"
+	"github.com/cu-library/almatoolkit/metrics"
+	"github.com/cu-library/almatoolkit/ratelimit"
 	"github.com/cu-library/almatoolkit/subcommand"
 	"github.com/cu-library/almatoolkit/subcommand/bibs/cleanupcallnumbers"
 	"github.com/cu-library/almatoolkit/subcommand/bibs/items/cancelrequests"
 	"github.com/cu-library/almatoolkit/subcommand/bibs/items/requests"
 	"github.com/cu-library/almatoolkit/subcommand/bibs/items/scanin"
+	"github.com/cu-library/almatoolkit/subcommand/checkpoint"
 	"github.com/cu-library/almatoolkit/subcommand/conf/dump"
+	"github.com/cu-library/almatoolkit/subcommand/plugin"
+	"github.com/cu-library/almatoolkit/subcommand/report"
 )
 
 const (
@@ -47,9 +52,31 @@ func main() {
 	key := flag.String("key", "", "The Alma API key. You can manage your API keys here: https://developers.exlibrisgroup.com/manage/keys/. Required.")
 	host := flag.String("host", api.DefaultAlmaAPIHost, "The Alma API host domain name to use.")
 	threshold := flag.Int("threshold", api.DefaultThreshold, "The minimum number of API calls remaining before the tool automatically stops working.")
+	output := flag.String("output", string(report.CSV), "The format subcommand reports are written in. One of: csv, json, ndjson, table.")
+	checkpointPath := flag.String("checkpoint", "", "Path to a checkpoint file recording per-member progress, so a set-processing subcommand killed mid-run can resume instead of starting over. Optional.")
+	metricsAddr := flag.String("metrics-addr", "", "Address to serve Prometheus metrics on, ex: :9090. Metrics are not served if this flag is unset.")
+	rateFlag := flag.Float64("rate", 0, "Maximum number of Alma API calls per second made by a subcommand's worker pool. 0 means unlimited.")
+	pluginDir := flag.String("plugin-dir", "", "Directory of Starlark plugin scripts, loaded as subcommands if no built-in or sibling executable subcommand matches. Optional. Script subcommands do not observe cancellation and are not subject to --rate.")
 	printVersion := flag.Bool("version", false, "Print the version then exit.")
 	printHelp := flag.Bool("help", false, "Print help documentation then exit.")
 
+	// outputAwareSubcommands names the subcommands whose Run actually
+	// consults report.New, rather than writing csv.NewWriter(os.Stdout)
+	// directly. requests, scanin, and cleanupcallnumbers aren't migrated
+	// yet; --output is rejected for them below instead of silently doing
+	// nothing.
+	outputAwareSubcommands := map[string]bool{
+		"items-cancel-requests": true,
+	}
+
+	// checkpointAwareSubcommands names the subcommands whose Run actually
+	// consults the checkpoint package. requests, scanin, and
+	// cleanupcallnumbers aren't migrated yet; --checkpoint is rejected
+	// for them below instead of silently doing nothing.
+	checkpointAwareSubcommands := map[string]bool{
+		"items-cancel-requests": true,
+	}
+
 	// Subcommands this tool understands.
 	registry := subcommand.Registry{}
 	registry.Register(dump.Config(EnvPrefix))
@@ -100,6 +127,9 @@ func main() {
 	if *key == "" {
 		log.Fatalln("FATAL: An Alma API key is required.")
 	}
+	if !report.ValidFormat(*output) {
+		log.Fatalf("FATAL: \"%v\" is not a valid output format.\n", *output)
+	}
 
 	// Was a subcommand provided? Was it valid?
 	if len(flag.Args()) == 0 {
@@ -107,10 +137,49 @@ func main() {
 	}
 	subName := flag.Args()[0]
 	sub, valid := registry[subName]
+	if !valid {
+		// Not a built-in subcommand. Look for a sibling executable named
+		// almatoolkit-<subName> on $PATH first, git-style, then fall back
+		// to a Starlark script in --plugin-dir.
+		if execPath, found := plugin.FindExecutable(subName); found {
+			err := plugin.RunExecutable(context.Background(), execPath, flag.Args()[1:], EnvPrefix, flag.CommandLine)
+			if err != nil {
+				log.Fatalf("FATAL: %v.\n", err)
+			}
+			os.Exit(0)
+		}
+		if scriptPath, found := plugin.FindScript(*pluginDir, subName); found {
+			script, err := plugin.LoadScript(scriptPath)
+			if err != nil {
+				log.Fatalf("FATAL: %v.\n", err)
+			}
+			sub = &subcommand.Config{
+				ReadAccess:    script.ReadAccess,
+				WriteAccess:   script.WriteAccess,
+				FlagSet:       flag.NewFlagSet(subName, flag.ExitOnError),
+				ValidateFlags: script.Validate,
+				Run:           script.Run,
+			}
+			valid = true
+		}
+	}
 	if !valid {
 		log.Fatalf("FATAL: \"%v\" is not a valid subcommand.\n", subName)
 	}
 
+	// --output is only honored by subcommands that actually consult
+	// report.New. Reject it outright for any other subcommand rather
+	// than let it silently do nothing.
+	if *output != string(report.CSV) && !outputAwareSubcommands[subName] {
+		log.Fatalf("FATAL: \"%v\" does not support --output %v yet; it always writes csv.\n", subName, *output)
+	}
+	// --checkpoint is only honored by subcommands that actually consult
+	// the checkpoint package. Reject it outright for any other
+	// subcommand rather than let it silently do nothing.
+	if *checkpointPath != "" && !checkpointAwareSubcommands[subName] {
+		log.Fatalf("FATAL: \"%v\" does not support --checkpoint yet.\n", subName)
+	}
+
 	// Ignore errors; FlagSets are all set for ExitOnError.
 	_ = sub.FlagSet.Parse(flag.Args()[1:])
 	// If any flags have not been set, see if there are
@@ -126,11 +195,28 @@ func main() {
 		}
 	}
 
+	// If a checkpoint file was requested, open it now. Its fingerprint covers
+	// the subcommand and its flag values, other than dispatch-only flags like
+	// --concurrency, so a checkpoint left behind by an unrelated run is
+	// rejected instead of silently reused.
+	var cp *checkpoint.Checkpoint
+	if *checkpointPath != "" {
+		fingerprint := checkpoint.Fingerprint(subName, sub.FlagSet)
+		cp, err = checkpoint.Open(*checkpointPath, subName, fingerprint)
+		if err != nil {
+			log.Fatalf("FATAL: %v.\n", err)
+		}
+		defer cp.Close()
+	}
+
 	// Keep track of child goroutines.
 	var wg sync.WaitGroup
 
 	// Our base context, used to derive all other contexts and propigrate cancel signals.
 	ctx, cancel := context.WithCancel(context.Background())
+	ctx = report.NewContext(ctx, *output)
+	ctx = checkpoint.NewContext(ctx, cp)
+	ctx = ratelimit.NewContext(ctx, *rateFlag, *threshold)
 
 	// Cancel the base context if SIGINT or SIGTERM are recieved.
 	wg.Add(1)
@@ -141,11 +227,25 @@ func main() {
 		select {
 		case <-sigs:
 			log.Println("Cancelling...")
+			if err := cp.Flush(); err != nil {
+				log.Printf("WARNING: error flushing checkpoint: %v\n", err)
+			}
 			cancel()
 		case <-ctx.Done():
 		}
 	}()
 
+	// If a metrics address was given, serve Prometheus metrics until ctx is cancelled.
+	if *metricsAddr != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := metrics.Serve(ctx, *metricsAddr); err != nil {
+				log.Printf("WARNING: metrics server stopped with an error: %v\n", err)
+			}
+		}()
+	}
+
 	// Initialize the API client.
 	c := api.NewClient(*host, *key, *threshold)
 
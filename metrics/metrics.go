@@ -0,0 +1,88 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+// Package metrics defines the Prometheus metrics almatoolkit exposes while
+// running a long batch job against the Alma API, and a small HTTP server
+// to serve them, so an operator can watch a run in Grafana and alert
+// before the Alma API key's daily call threshold trips.
+package metrics
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// APICallsTotal counts Alma API calls, by endpoint and status: the
+	// HTTP status code of the response, or "error" if the call returned
+	// an error instead of a response.
+	APICallsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "almatoolkit",
+		Name:      "api_calls_total",
+		Help:      "Total number of Alma API calls made, by endpoint and status.",
+	}, []string{"endpoint", "status"})
+
+	// APICallDurationSeconds records how long each Alma API call took, by
+	// endpoint.
+	APICallDurationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "almatoolkit",
+		Name:      "api_call_duration_seconds",
+		Help:      "Latency of Alma API calls, by endpoint.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	// APICallsRemaining reports the most recently seen value of Alma's
+	// X-Exl-Api-Remaining response header, the number of API calls left
+	// before the daily threshold trips.
+	APICallsRemaining = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "almatoolkit",
+		Name:      "api_calls_remaining",
+		Help:      "Remaining Alma API calls before the daily threshold, as last reported by the X-Exl-Api-Remaining header.",
+	})
+
+	// SetMembersProcessedTotal counts set members a subcommand has
+	// finished processing, by subcommand.
+	SetMembersProcessedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "almatoolkit",
+		Name:      "set_members_processed_total",
+		Help:      "Total number of set members processed, by subcommand.",
+	}, []string{"subcommand"})
+
+	// MutationsTotal counts mutations a subcommand has attempted, by
+	// subcommand and outcome ("success" or "failed").
+	MutationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "almatoolkit",
+		Name:      "mutations_total",
+		Help:      "Total number of mutations attempted, by subcommand and outcome.",
+	}, []string{"subcommand", "outcome"})
+)
+
+var (
+	remainingMu  sync.Mutex
+	remaining    float64
+	remainingSet bool
+)
+
+// SetRemaining records the most recently seen value of Alma's
+// X-Exl-Api-Remaining response header, for both the APICallsRemaining
+// gauge and Remaining. A RoundTripper calls this as responses come in.
+func SetRemaining(n float64) {
+	APICallsRemaining.Set(n)
+	remainingMu.Lock()
+	defer remainingMu.Unlock()
+	remaining = n
+	remainingSet = true
+}
+
+// Remaining returns the most recently observed value set by
+// SetRemaining, and whether any value has been observed yet. Before the
+// first response carrying X-Exl-Api-Remaining, ok is false.
+func Remaining() (float64, bool) {
+	remainingMu.Lock()
+	defer remainingMu.Unlock()
+	return remaining, remainingSet
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// RemainingHeader is the Alma response header api.Client reads to decide
+// when to stop making calls, and which RoundTripper mirrors into the
+// APICallsRemaining gauge.
+const RemainingHeader = "X-Exl-Api-Remaining"
+
+// RoundTripper wraps another http.RoundTripper, recording APICallsTotal,
+// APICallDurationSeconds, and APICallsRemaining for every request it
+// makes. The endpoint label is the request path normalized by
+// normalizeEndpoint, not the raw path, so Alma IDs embedded in the path
+// (mms_id, holding_id, item_pid, request_id, and so on) don't each
+// create their own time series.
+//
+// Nothing in this package installs a RoundTripper as api.Client's
+// transport; api.NewClient needs to wrap whatever *http.Client (or
+// http.RoundTripper) it builds, e.g.:
+//
+//	httpClient.Transport = metrics.RoundTripper{Next: httpClient.Transport}
+//
+// until that's done, APICallsTotal, APICallDurationSeconds, and
+// APICallsRemaining stay at zero, and ratelimit.BelowThreshold (which
+// reads APICallsRemaining via Remaining) never reports true.
+type RoundTripper struct {
+	Next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := rt.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	endpoint := normalizeEndpoint(req.URL.Path)
+	start := time.Now()
+	resp, err := next.RoundTrip(req)
+	APICallDurationSeconds.WithLabelValues(endpoint).Observe(time.Since(start).Seconds())
+	if err != nil {
+		APICallsTotal.WithLabelValues(endpoint, "error").Inc()
+		return resp, err
+	}
+	APICallsTotal.WithLabelValues(endpoint, strconv.Itoa(resp.StatusCode)).Inc()
+	if remaining := resp.Header.Get(RemainingHeader); remaining != "" {
+		if n, parseErr := strconv.ParseFloat(remaining, 64); parseErr == nil {
+			SetRemaining(n)
+		}
+	}
+	return resp, nil
+}
+
+// normalizeEndpoint collapses a request path into a route template
+// suitable for use as a Prometheus label, by replacing any path segment
+// made up entirely of digits with ":id". Alma paths embed record identifiers
+// (mms_id, holding_id, item_pid, request_id) directly in the path, e.g.
+// /almaws/v1/bibs/9912345/holdings/221/items/234/requests/56, which
+// would otherwise produce one time series per record instead of one
+// per route.
+func normalizeEndpoint(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if allDigits(segment) {
+			segments[i] = ":id"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func allDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
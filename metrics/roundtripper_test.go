@@ -0,0 +1,26 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package metrics
+
+import "testing"
+
+func TestNormalizeEndpoint(t *testing.T) {
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/almaws/v1/conf/code-tables/RequestCancellationReasons", "/almaws/v1/conf/code-tables/RequestCancellationReasons"},
+		{"/almaws/v1/bibs/9912345/holdings/221/items/234", "/almaws/v1/bibs/:id/holdings/:id/items/:id"},
+		{"/almaws/v1/bibs/9912345/holdings/221/items/234/requests/56", "/almaws/v1/bibs/:id/holdings/:id/items/:id/requests/:id"},
+		{"/almaws/v1/conf/sets/123456789", "/almaws/v1/conf/sets/:id"},
+		{"/", "/"},
+	}
+	for _, c := range cases {
+		if got := normalizeEndpoint(c.path); got != c.want {
+			t.Errorf("normalizeEndpoint(%q) = %q, want %q", c.path, got, c.want)
+		}
+	}
+}
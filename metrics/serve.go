@@ -0,0 +1,38 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Serve starts an HTTP server on addr exposing the registered metrics at
+// /metrics. It blocks until ctx is cancelled, then shuts the server down
+// and returns.
+func Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case <-ctx.Done():
+		return srv.Close()
+	case err := <-errCh:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
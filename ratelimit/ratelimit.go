@@ -0,0 +1,77 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+// Package ratelimit threads a token-bucket rate limiter through a
+// context.Context, so that a worker pool processing set members
+// concurrently can throttle itself to a fixed number of Alma API calls
+// per second, the same way subcommand/report and subcommand/checkpoint
+// thread their state through a context. It also carries the --threshold
+// value, so a worker pool's dispatcher can stop handing out new work
+// once the Alma API key's remaining daily calls dip below it, the same
+// threshold api.Client itself stops on.
+package ratelimit
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+
+	"github.com/cu-library/almatoolkit/metrics"
+)
+
+type contextKey int
+
+const (
+	limiterContextKey   contextKey = 0
+	thresholdContextKey contextKey = 1
+)
+
+// NewContext returns a copy of ctx which carries a limiter allowing
+// ratePerSecond calls to Wait per second, and threshold, the minimum
+// number of Alma API calls which must remain before BelowThreshold
+// reports true. A ratePerSecond of zero or less means unlimited calls
+// per second. A threshold of zero or less means BelowThreshold never
+// reports true.
+func NewContext(ctx context.Context, ratePerSecond float64, threshold int) context.Context {
+	if ratePerSecond > 0 {
+		ctx = context.WithValue(ctx, limiterContextKey, rate.NewLimiter(rate.Limit(ratePerSecond), 1))
+	}
+	if threshold > 0 {
+		ctx = context.WithValue(ctx, thresholdContextKey, threshold)
+	}
+	return ctx
+}
+
+// Wait blocks until ctx's limiter permits another call, or ctx is done.
+// If ctx carries no limiter, Wait returns immediately.
+func Wait(ctx context.Context) error {
+	limiter, ok := ctx.Value(limiterContextKey).(*rate.Limiter)
+	if !ok {
+		return nil
+	}
+	return limiter.Wait(ctx)
+}
+
+// BelowThreshold reports whether the most recently observed count of
+// remaining Alma API calls has dipped below ctx's threshold. It reports
+// false if ctx carries no threshold, or if no remaining count has been
+// observed yet.
+//
+// The remaining count is observed through metrics.Remaining, which only
+// ever gets a value once something calls metrics.SetRemaining -- today
+// that's metrics.RoundTripper, which isn't installed as api.Client's
+// transport (see metrics.RoundTripper's doc comment). Until that's
+// wired up, BelowThreshold always reports false.
+func BelowThreshold(ctx context.Context) bool {
+	threshold, ok := ctx.Value(thresholdContextKey).(int)
+	if !ok {
+		return false
+	}
+	remaining, ok := metrics.Remaining()
+	if !ok {
+		return false
+	}
+	return remaining < float64(threshold)
+}
@@ -0,0 +1,44 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cu-library/almatoolkit/metrics"
+)
+
+func TestBelowThresholdNeedsAThresholdAndAnObservedRemaining(t *testing.T) {
+	ctx := context.Background()
+
+	if BelowThreshold(ctx) {
+		t.Error("BelowThreshold with no threshold in ctx = true, want false")
+	}
+
+	withThreshold := NewContext(ctx, 0, 100)
+	if BelowThreshold(withThreshold) {
+		t.Error("BelowThreshold before any remaining count is observed = true, want false")
+	}
+
+	metrics.SetRemaining(50)
+	if !BelowThreshold(withThreshold) {
+		t.Error("BelowThreshold(remaining=50, threshold=100) = false, want true")
+	}
+
+	metrics.SetRemaining(500)
+	if BelowThreshold(withThreshold) {
+		t.Error("BelowThreshold(remaining=500, threshold=100) = true, want false")
+	}
+}
+
+func TestNewContextZeroThresholdNeverTrips(t *testing.T) {
+	ctx := NewContext(context.Background(), 0, 0)
+	metrics.SetRemaining(0)
+	if BelowThreshold(ctx) {
+		t.Error("BelowThreshold with threshold 0 = true, want false: 0 means unbounded, same as NewContext's rate handling")
+	}
+}
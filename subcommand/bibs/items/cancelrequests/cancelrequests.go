@@ -8,14 +8,16 @@ package cancelrequests
 
 import (
 	"context"
-	"encoding/csv"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 
 	"github.com/cu-library/almatoolkit/api"
+	"github.com/cu-library/almatoolkit/metrics"
 	"github.com/cu-library/almatoolkit/subcommand"
+	"github.com/cu-library/almatoolkit/subcommand/checkpoint"
+	"github.com/cu-library/almatoolkit/subcommand/report"
 )
 
 // Config returns a new subcommand config.
@@ -28,6 +30,7 @@ func Config(envPrefix string) *subcommand.Config {
 	reason := fs.String("reason", "", "Code of the cancel reason. Must be a value from the code table 'RequestCancellationReasons'.")
 	note := fs.String("note", "", "Note with additional information regarding the cancellation")
 	dryrun := fs.Bool("dryrun", false, "Do not perform any updates. Report on what changes would have been made.")
+	concurrency := fs.Int("concurrency", 1, "Number of requests to cancel concurrently.")
 	fs.Usage = func() {
 		description := "Cancel item requests of type and/or subtype on items in the given set."
 		subcommand.Usage(fs, envPrefix, description)
@@ -47,6 +50,9 @@ func Config(envPrefix string) *subcommand.Config {
 			if *reason == "" {
 				return fmt.Errorf("a reason is required, try the 'dump-conf' subcommand to find a value from the 'RequestCancellationReasons' table")
 			}
+			if *concurrency < 1 {
+				return fmt.Errorf("concurrency must be at least 1")
+			}
 			return nil
 		},
 		Run: func(ctx context.Context, c *api.Client) error {
@@ -69,6 +75,7 @@ func Config(envPrefix string) *subcommand.Config {
 				}
 				return fmt.Errorf("%v error(s) occured when retrieving the members of '%v' (ID %v)", len(errs), set.Name, set.ID)
 			}
+			metrics.SetMembersProcessedTotal.WithLabelValues(fs.Name()).Add(float64(len(members)))
 			requests, errs := c.ItemMembersUserRequests(ctx, members)
 			if len(errs) != 0 {
 				for _, err := range errs {
@@ -86,19 +93,56 @@ func Config(envPrefix string) *subcommand.Config {
 			for _, request := range matching {
 				matchingMap[request.Link] = true
 			}
-			cancelled := []api.UserRequest{}
+			// Skip requests a previous, interrupted run already recorded as
+			// successfully cancelled, so resuming from a checkpoint doesn't
+			// resubmit cancellations that already went through. Requests
+			// recorded as failed are attempted again.
+			cp := checkpoint.FromContext(ctx)
+			toCancel := []api.UserRequest{}
+			for _, request := range matching {
+				if !cp.Done(request.Link) {
+					toCancel = append(toCancel, request)
+				}
+			}
+			cancelledMap := map[string]bool{}
 			errs = []error{}
 			if !*dryrun {
-				cancelled, errs = c.UserRequestsCancel(ctx, matching, *reason, *note)
+				cancelled, attempted, cancelErrs := cancelConcurrently(ctx, c, toCancel, *reason, *note, *concurrency)
+				errs = cancelErrs
+				// Only record and count outcomes for requests actually attempted.
+				// A request never dispatched, because ctx was cancelled or the
+				// --threshold was reached first, is left out entirely, so it's
+				// retried on resume instead of being recorded (and counted) as a
+				// failure.
+				for i, request := range toCancel {
+					if !attempted[i] {
+						continue
+					}
+					outcome := checkpoint.Failed
+					metricOutcome := "failed"
+					if cancelled[i] {
+						cancelledMap[request.Link] = true
+						outcome = checkpoint.Success
+						metricOutcome = "success"
+					}
+					metrics.MutationsTotal.WithLabelValues(fs.Name(), metricOutcome).Inc()
+					if err := cp.Record(request.Link, outcome); err != nil {
+						return fmt.Errorf("error recording checkpoint: %w", err)
+					}
+				}
 			}
-			cancelledMap := map[string]bool{}
-			for _, request := range cancelled {
-				cancelledMap[request.Link] = true
+			for _, request := range matching {
+				if outcome, ok := cp.Outcome(request.Link); ok && outcome == checkpoint.Success {
+					cancelledMap[request.Link] = true
+				}
+			}
+			w, err := report.New(ctx, os.Stdout)
+			if err != nil {
+				return err
 			}
-			w := csv.NewWriter(os.Stdout)
-			err = w.Write([]string{"Request Link", "Request Type", "Request Subtype", "Matched type and subtype", "Cancelled in Alma"})
+			err = w.Header([]string{"Request Link", "Request Type", "Request Subtype", "Matched type and subtype", "Cancelled in Alma"})
 			if err != nil {
-				return fmt.Errorf("error writing csv header: %w", err)
+				return fmt.Errorf("error writing report header: %w", err)
 			}
 			for _, request := range requests {
 				line := []string{request.Link, request.Type, request.SubType}
@@ -114,17 +158,16 @@ func Config(envPrefix string) *subcommand.Config {
 				} else {
 					line = append(line, "no")
 				}
-				err := w.Write(line)
+				err := w.Row(line)
 				if err != nil {
-					return fmt.Errorf("error writing line to csv: %w", err)
+					return fmt.Errorf("error writing report row: %w", err)
 				}
 			}
-			w.Flush()
-			err = w.Error()
+			err = w.Flush()
 			if err != nil {
-				return fmt.Errorf("error after flushing csv: %w", err)
+				return fmt.Errorf("error flushing report: %w", err)
 			}
-			log.Printf("%v request(s) cancelled.\n", len(cancelled))
+			log.Printf("%v request(s) cancelled.\n", len(cancelledMap))
 			if len(errs) != 0 {
 				for _, err := range errs {
 					log.Println(err)
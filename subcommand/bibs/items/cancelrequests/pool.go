@@ -0,0 +1,102 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package cancelrequests
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cu-library/almatoolkit/api"
+	"github.com/cu-library/almatoolkit/ratelimit"
+)
+
+// cancelResult is the outcome of cancelling a single request, tagged
+// with its position in the slice passed to cancelConcurrently so
+// results can be reassembled in the original order.
+type cancelResult struct {
+	index     int
+	attempted bool
+	cancelled bool
+	err       error
+}
+
+// cancelConcurrently cancels each of requests using up to concurrency
+// workers at once, throttled by the rate limiter (if any) carried on
+// ctx, and stopping early once ratelimit.BelowThreshold(ctx) reports
+// the remaining Alma API call count has dipped below the configured
+// threshold. It returns, in the same order as requests, whether each
+// request was cancelled and whether it was actually attempted.
+//
+// A request is "attempted" only if an Alma API call was made for it.
+// Requests never dispatched, because ctx was cancelled or the
+// threshold was reached first, are left unattempted rather than
+// recorded as failures, so the caller can leave them out of its
+// checkpoint and metrics and let them be retried on resume.
+func cancelConcurrently(ctx context.Context, c *api.Client, requests []api.UserRequest, reason, note string, concurrency int) (cancelled []bool, attempted []bool, errs []error) {
+	cancelled = make([]bool, len(requests))
+	attempted = make([]bool, len(requests))
+	if len(requests) == 0 {
+		return cancelled, attempted, nil
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobs := make(chan int)
+	results := make(chan cancelResult)
+
+	var workers sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for index := range jobs {
+				if err := ratelimit.Wait(ctx); err != nil {
+					// No Alma API call was made for this request; leave
+					// it unattempted so it's retried on resume instead
+					// of recorded as a failure.
+					results <- cancelResult{index: index}
+					continue
+				}
+				done, errs := c.UserRequestsCancel(ctx, []api.UserRequest{requests[index]}, reason, note)
+				result := cancelResult{index: index, attempted: true, cancelled: len(done) == 1}
+				if len(errs) != 0 {
+					result.err = fmt.Errorf("%v: %w", requests[index].Link, errs[0])
+				}
+				results <- result
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for index := range requests {
+			if ratelimit.BelowThreshold(ctx) {
+				return
+			}
+			select {
+			case jobs <- index:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	for result := range results {
+		attempted[result.index] = result.attempted
+		cancelled[result.index] = result.cancelled
+		if result.err != nil {
+			errs = append(errs, result.err)
+		}
+	}
+	return cancelled, attempted, errs
+}
@@ -0,0 +1,247 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+// Package checkpoint lets long-running, set-member-processing subcommands
+// record their progress to disk, so a run killed partway through (by a
+// SIGINT/SIGTERM, or because the Alma API key's daily call threshold was
+// hit) can be resumed instead of reprocessing members it already handled.
+//
+// The checkpoint file is one JSON object per line: a header line carrying
+// a fingerprint of the subcommand and its flags, followed by one entry
+// line per recorded outcome. The format is deliberately append-only, so a
+// file left behind by a crash is still valid up to its last complete
+// line.
+package checkpoint
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Outcome records what happened the last time a member was processed.
+type Outcome string
+
+// Recognized outcomes.
+const (
+	Success Outcome = "success"
+	Failed  Outcome = "failed"
+)
+
+type header struct {
+	Subcommand  string `json:"subcommand"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+type entry struct {
+	Link    string  `json:"link"`
+	Outcome Outcome `json:"outcome"`
+}
+
+// Checkpoint is a handle on an open checkpoint file.
+type Checkpoint struct {
+	mu   sync.Mutex
+	file *os.File
+	done map[string]Outcome
+}
+
+// dispatchOnlyFlags names flags which only affect how fast or how
+// concurrently a subcommand does its work, not which members it
+// processes or what it does to them. Fingerprint ignores them, so
+// resuming an interrupted run with a different --concurrency is not
+// rejected as a flag mismatch.
+var dispatchOnlyFlags = map[string]bool{
+	"concurrency": true,
+	"rate":        true,
+}
+
+// Fingerprint returns a stable hash of the subcommand name and the values
+// of every flag in fs, excluding dispatchOnlyFlags. Two runs of the same
+// subcommand with different flag values (a different set, a different
+// reason code, and so on) produce different fingerprints, so Open can
+// refuse to resume from a checkpoint file left behind by an unrelated
+// run.
+func Fingerprint(subcommand string, fs *flag.FlagSet) string {
+	values := map[string]string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if dispatchOnlyFlags[f.Name] {
+			return
+		}
+		values[f.Name] = f.Value.String()
+	})
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	fmt.Fprint(h, subcommand)
+	for _, name := range names {
+		fmt.Fprintf(h, "\x00%v=%v", name, values[name])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Open opens the checkpoint file at path, creating it (and writing its
+// header line) if it does not already exist.
+//
+// If the file already exists, its header is checked against subcommand
+// and fingerprint. A mismatch is returned as an error rather than
+// resumed from, so a stale or unrelated checkpoint file can't silently
+// corrupt a new run. Any trailing incomplete line, left behind by a
+// crash mid-write, is discarded.
+func Open(path, subcommand, fingerprint string) (*Checkpoint, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening checkpoint file %v: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error reading checkpoint file %v: %w", path, err)
+	}
+	if info.Size() == 0 {
+		c := &Checkpoint{file: f, done: map[string]Outcome{}}
+		if err := c.writeHeader(subcommand, fingerprint); err != nil {
+			f.Close()
+			return nil, err
+		}
+		return c, nil
+	}
+	done, validBytes, err := readEntries(f, subcommand, fingerprint)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := f.Truncate(validBytes); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error truncating checkpoint file %v: %w", path, err)
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error seeking checkpoint file %v: %w", path, err)
+	}
+	return &Checkpoint{file: f, done: done}, nil
+}
+
+func (c *Checkpoint) writeHeader(subcommand, fingerprint string) error {
+	line, err := json.Marshal(header{Subcommand: subcommand, Fingerprint: fingerprint})
+	if err != nil {
+		return fmt.Errorf("error marshalling checkpoint header: %w", err)
+	}
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing checkpoint header: %w", err)
+	}
+	return c.file.Sync()
+}
+
+// readEntries reads a checkpoint file's header and entries, verifying the
+// header against subcommand and fingerprint, and returns the recorded
+// outcomes along with the number of bytes making up complete lines.
+func readEntries(f *os.File, subcommand, fingerprint string) (map[string]Outcome, int64, error) {
+	r := bufio.NewReader(f)
+	headerLine, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, 0, fmt.Errorf("error reading checkpoint header: %w", err)
+	}
+	var h header
+	if err := json.Unmarshal([]byte(strings.TrimRight(headerLine, "\n")), &h); err != nil {
+		return nil, 0, fmt.Errorf("checkpoint file has a corrupt header: %w", err)
+	}
+	if h.Subcommand != subcommand || h.Fingerprint != fingerprint {
+		return nil, 0, fmt.Errorf("checkpoint file was recorded for a different subcommand or set of flags, refusing to resume from it")
+	}
+	validBytes := int64(len(headerLine))
+	done := map[string]Outcome{}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			// The end of the file, or an unterminated line left behind by
+			// a crash. Stop reading; Open will truncate the file back to
+			// validBytes.
+			break
+		}
+		var e entry
+		if decodeErr := json.Unmarshal([]byte(strings.TrimRight(line, "\n")), &e); decodeErr != nil {
+			// An incomplete line left behind by a crash. Stop reading;
+			// Open will truncate the file back to validBytes.
+			break
+		}
+		done[e.Link] = e.Outcome
+		validBytes += int64(len(line))
+	}
+	return done, validBytes, nil
+}
+
+// Done reports whether link already succeeded on a previous run. Only
+// Success is terminal: a link recorded as Failed, like one never
+// recorded at all, is retried on resume. A nil Checkpoint always
+// reports false, so callers don't need to special case subcommands run
+// without a checkpoint file.
+func (c *Checkpoint) Done(link string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.done[link] == Success
+}
+
+// Outcome returns the outcome recorded for link, if any. A nil Checkpoint
+// always reports !ok.
+func (c *Checkpoint) Outcome(link string) (outcome Outcome, ok bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	outcome, ok = c.done[link]
+	return outcome, ok
+}
+
+// Record appends an outcome for link to the checkpoint file and syncs it
+// to disk. A nil Checkpoint is a no-op.
+func (c *Checkpoint) Record(link string, outcome Outcome) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	line, err := json.Marshal(entry{Link: link, Outcome: outcome})
+	if err != nil {
+		return fmt.Errorf("error marshalling checkpoint entry: %w", err)
+	}
+	if _, err := c.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing checkpoint entry: %w", err)
+	}
+	c.done[link] = outcome
+	return c.file.Sync()
+}
+
+// Flush syncs the checkpoint file to disk. A nil Checkpoint is a no-op.
+func (c *Checkpoint) Flush() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.file.Sync()
+}
+
+// Close closes the checkpoint file. A nil Checkpoint is a no-op.
+func (c *Checkpoint) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.file.Close()
+}
@@ -0,0 +1,166 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package checkpoint
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDoneOnlyTerminalOnSuccess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	cp, err := Open(path, "sub", "fingerprint")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer cp.Close()
+
+	if err := cp.Record("success-link", Success); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := cp.Record("failed-link", Failed); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	if !cp.Done("success-link") {
+		t.Error("Done(success-link) = false, want true")
+	}
+	if cp.Done("failed-link") {
+		t.Error("Done(failed-link) = true, want false: a failed link must be retried on resume")
+	}
+	if cp.Done("never-recorded-link") {
+		t.Error("Done(never-recorded-link) = true, want false")
+	}
+}
+
+func TestOpenResumesRecordedOutcomes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	fs := flag.NewFlagSet("sub", flag.ContinueOnError)
+	fingerprint := Fingerprint("sub", fs)
+
+	cp, err := Open(path, "sub", fingerprint)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := cp.Record("link-a", Success); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := cp.Record("link-b", Failed); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := cp.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	resumed, err := Open(path, "sub", fingerprint)
+	if err != nil {
+		t.Fatalf("Open (resume): %v", err)
+	}
+	defer resumed.Close()
+
+	if !resumed.Done("link-a") {
+		t.Error("Done(link-a) = false after resume, want true")
+	}
+	if resumed.Done("link-b") {
+		t.Error("Done(link-b) = true after resume, want false")
+	}
+	outcome, ok := resumed.Outcome("link-b")
+	if !ok || outcome != Failed {
+		t.Errorf("Outcome(link-b) = (%v, %v), want (%v, true)", outcome, ok, Failed)
+	}
+}
+
+func TestOpenDiscardsTrailingPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	fs := flag.NewFlagSet("sub", flag.ContinueOnError)
+	fingerprint := Fingerprint("sub", fs)
+
+	cp, err := Open(path, "sub", fingerprint)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := cp.Record("whole-link", Success); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if err := cp.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Simulate a crash mid-write: append a truncated, unterminated entry
+	// line after the last complete one.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	if _, err := f.WriteString(`{"link":"partial-link","outc`); err != nil {
+		t.Fatalf("WriteString: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	resumed, err := Open(path, "sub", fingerprint)
+	if err != nil {
+		t.Fatalf("Open (after crash): %v", err)
+	}
+	defer resumed.Close()
+
+	if !resumed.Done("whole-link") {
+		t.Error("Done(whole-link) = false, want true: complete entries must survive a truncated trailing line")
+	}
+	if _, ok := resumed.Outcome("partial-link"); ok {
+		t.Error("Outcome(partial-link) ok = true, want false: the truncated line must be discarded")
+	}
+
+	// The truncated line must actually have been removed from the file
+	// on disk, not just ignored in memory, so a later Record doesn't
+	// append after corrupt bytes.
+	if err := resumed.Record("another-link", Success); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	reopened, err := Open(path, "sub", fingerprint)
+	if err != nil {
+		t.Fatalf("Open (after truncation check): %v", err)
+	}
+	defer reopened.Close()
+	if !reopened.Done("another-link") {
+		t.Error("Done(another-link) = false, want true: the file must stay well-formed after truncation")
+	}
+}
+
+func TestFingerprintIgnoresDispatchOnlyFlags(t *testing.T) {
+	fsA := flag.NewFlagSet("sub", flag.ContinueOnError)
+	concurrencyA := fsA.Int("concurrency", 1, "")
+	rateA := fsA.Float64("rate", 0, "")
+	*concurrencyA = 1
+	*rateA = 0
+
+	fsB := flag.NewFlagSet("sub", flag.ContinueOnError)
+	concurrencyB := fsB.Int("concurrency", 1, "")
+	rateB := fsB.Float64("rate", 0, "")
+	*concurrencyB = 8
+	*rateB = 2.5
+
+	if got, want := Fingerprint("sub", fsA), Fingerprint("sub", fsB); got != want {
+		t.Errorf("Fingerprint with different --concurrency/--rate = %v, want %v (dispatch-only flags must not affect resumability)", got, want)
+	}
+}
+
+func TestFingerprintCoversOtherFlags(t *testing.T) {
+	fsA := flag.NewFlagSet("sub", flag.ContinueOnError)
+	setidA := fsA.String("setid", "", "")
+	*setidA = "1"
+
+	fsB := flag.NewFlagSet("sub", flag.ContinueOnError)
+	setidB := fsB.String("setid", "", "")
+	*setidB = "2"
+
+	if got, unwanted := Fingerprint("sub", fsA), Fingerprint("sub", fsB); got == unwanted {
+		t.Errorf("Fingerprint with different --setid = %v, want it to differ from %v", got, unwanted)
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package checkpoint
+
+import "context"
+
+type contextKey int
+
+const checkpointContextKey contextKey = 0
+
+// NewContext returns a copy of ctx which carries c.
+func NewContext(ctx context.Context, c *Checkpoint) context.Context {
+	return context.WithValue(ctx, checkpointContextKey, c)
+}
+
+// FromContext returns the Checkpoint stored in ctx by NewContext, or nil
+// if ctx carries none.
+func FromContext(ctx context.Context) *Checkpoint {
+	c, _ := ctx.Value(checkpointContextKey).(*Checkpoint)
+	return c
+}
@@ -0,0 +1,101 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+
+	"github.com/cu-library/almatoolkit/api"
+)
+
+// clientValue builds the Starlark "client" object passed to a plugin
+// script's run(ctx, client) function. It exposes the subset of
+// api.Client methods a script is allowed to call: set_from_name_or_id,
+// set_members, item_members_user_requests, and user_requests_cancel.
+func clientValue(ctx context.Context, c *api.Client) starlark.Value {
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"set_from_name_or_id": starlark.NewBuiltin("set_from_name_or_id",
+			func(_ *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+				var name, id string
+				if err := starlark.UnpackArgs("set_from_name_or_id", args, kwargs, "name?", &name, "id?", &id); err != nil {
+					return nil, err
+				}
+				set, err := c.SetFromNameOrID(ctx, name, id)
+				if err != nil {
+					return nil, err
+				}
+				return &setValue{set: set}, nil
+			}),
+
+		"set_members": starlark.NewBuiltin("set_members",
+			func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+				v, err := onePositionalArg(b, args, kwargs)
+				if err != nil {
+					return nil, err
+				}
+				set, ok := v.(*setValue)
+				if !ok {
+					return nil, fmt.Errorf("%v: expected a set, got %v", b.Name(), v.Type())
+				}
+				members, errs := c.SetMembers(ctx, set.set)
+				if len(errs) != 0 {
+					return nil, errs[0]
+				}
+				return &membersValue{members: members}, nil
+			}),
+
+		"item_members_user_requests": starlark.NewBuiltin("item_members_user_requests",
+			func(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+				v, err := onePositionalArg(b, args, kwargs)
+				if err != nil {
+					return nil, err
+				}
+				members, ok := v.(*membersValue)
+				if !ok {
+					return nil, fmt.Errorf("%v: expected members, got %v", b.Name(), v.Type())
+				}
+				requests, errs := c.ItemMembersUserRequests(ctx, members.members)
+				if len(errs) != 0 {
+					return nil, errs[0]
+				}
+				return requestsToList(requests), nil
+			}),
+
+		"user_requests_cancel": starlark.NewBuiltin("user_requests_cancel",
+			func(_ *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+				var requestsList *starlark.List
+				var reason, note string
+				if err := starlark.UnpackArgs("user_requests_cancel", args, kwargs, "requests", &requestsList, "reason", &reason, "note?", &note); err != nil {
+					return nil, err
+				}
+				requests, err := requestsFromList(requestsList)
+				if err != nil {
+					return nil, err
+				}
+				cancelled, errs := c.UserRequestsCancel(ctx, requests, reason, note)
+				if len(errs) != 0 {
+					return nil, errs[0]
+				}
+				return requestsToList(cancelled), nil
+			}),
+	})
+}
+
+// onePositionalArg returns a builtin's single required positional
+// argument, rejecting keyword arguments and any other argument count.
+func onePositionalArg(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(kwargs) != 0 {
+		return nil, fmt.Errorf("%v: unexpected keyword arguments", b.Name())
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("%v: expected exactly one argument, got %v", b.Name(), len(args))
+	}
+	return args[0], nil
+}
@@ -0,0 +1,34 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package plugin
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// RunExecutable runs the sibling executable at path with args, forwarding
+// every flag in fs to it as envPrefix+FLAGNAME environment variables, the
+// same convention overridefromenv already reads flags from. The child
+// inherits the parent's environment, stdin, stdout, and stderr.
+func RunExecutable(ctx context.Context, path string, args []string, envPrefix string, fs *flag.FlagSet) error {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	fs.VisitAll(func(f *flag.Flag) {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%v%v=%v", envPrefix, strings.ToUpper(f.Name), f.Value.String()))
+	})
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running plugin %v: %w", path, err)
+	}
+	return nil
+}
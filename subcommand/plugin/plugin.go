@@ -0,0 +1,43 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+// Package plugin discovers and runs subcommands which are not compiled
+// into the almatoolkit binary, so libraries can add site-specific batch
+// operations without forking the repo. Two kinds of plugin are
+// supported:
+//
+//   - Sibling executables on $PATH named almatoolkit-<name>, git-style.
+//     They're invoked as a child process, with the parent's flags
+//     forwarded as ALMATOOLKIT_<FLAG> environment variables, the same
+//     convention overridefromenv already reads.
+//
+//   - Starlark scripts under a configurable --plugin-dir, which define
+//     read_access, write_access, an optional validate() function, and a
+//     run(ctx, client) function. client exposes a subset of api.Client's
+//     methods, so a script can drive a batch operation the same way a
+//     compiled-in subcommand does.
+package plugin
+
+import (
+	"os/exec"
+)
+
+// ExecutablePrefix is prepended to a subcommand name to form the
+// sibling executable FindExecutable looks for on $PATH.
+const ExecutablePrefix = "almatoolkit-"
+
+// ScriptExtension is the file extension FindScript looks for inside
+// --plugin-dir.
+const ScriptExtension = ".star"
+
+// FindExecutable looks for a sibling executable named
+// ExecutablePrefix+name on $PATH, returning its full path if found.
+func FindExecutable(name string) (path string, found bool) {
+	path, err := exec.LookPath(ExecutablePrefix + name)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
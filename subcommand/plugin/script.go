@@ -0,0 +1,117 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.starlark.net/starlark"
+
+	"github.com/cu-library/almatoolkit/api"
+)
+
+// Script is a subcommand implemented as a Starlark script loaded from
+// --plugin-dir. A valid script defines a run(ctx, client) function, and
+// may define read_access and write_access lists of Alma API path
+// prefixes, and a validate() function called before Run.
+type Script struct {
+	Path        string
+	ReadAccess  []string
+	WriteAccess []string
+
+	thread  *starlark.Thread
+	globals starlark.StringDict
+}
+
+// FindScript looks for name+ScriptExtension inside dir, returning its
+// full path if found. It returns false if dir is empty.
+func FindScript(dir, name string) (path string, found bool) {
+	if dir == "" {
+		return "", false
+	}
+	path = filepath.Join(dir, name+ScriptExtension)
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// LoadScript executes the top level of the Starlark script at path and
+// returns a Script wrapping it, after checking it defines a run
+// function and that read_access/write_access, if present, are lists of
+// strings.
+func LoadScript(path string) (*Script, error) {
+	thread := &starlark.Thread{Name: path}
+	globals, err := starlark.ExecFile(thread, path, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error loading plugin %v: %w", path, err)
+	}
+	if _, ok := globals["run"]; !ok {
+		return nil, fmt.Errorf("plugin %v does not define a run(ctx, client) function", path)
+	}
+	s := &Script{Path: path, thread: thread, globals: globals}
+	s.ReadAccess, err = stringListGlobal(globals, "read_access")
+	if err != nil {
+		return nil, fmt.Errorf("plugin %v: %w", path, err)
+	}
+	s.WriteAccess, err = stringListGlobal(globals, "write_access")
+	if err != nil {
+		return nil, fmt.Errorf("plugin %v: %w", path, err)
+	}
+	return s, nil
+}
+
+func stringListGlobal(globals starlark.StringDict, name string) ([]string, error) {
+	v, ok := globals[name]
+	if !ok {
+		return nil, nil
+	}
+	list, ok := v.(*starlark.List)
+	if !ok {
+		return nil, fmt.Errorf("%v must be a list of strings", name)
+	}
+	values := make([]string, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		s, ok := starlark.AsString(list.Index(i))
+		if !ok {
+			return nil, fmt.Errorf("%v must be a list of strings", name)
+		}
+		values = append(values, s)
+	}
+	return values, nil
+}
+
+// Validate calls the script's validate() function, if it defines one.
+func (s *Script) Validate() error {
+	validate, ok := s.globals["validate"]
+	if !ok {
+		return nil
+	}
+	if _, err := starlark.Call(s.thread, validate, nil, nil); err != nil {
+		return fmt.Errorf("plugin %v failed validation: %w", s.Path, err)
+	}
+	return nil
+}
+
+// Run calls the script's run(ctx, client) function, binding client to c.
+//
+// ctx is currently passed through as None, not a cancellation handle: a
+// running script cannot observe SIGINT, and because clientValue's
+// builtins call c's methods directly, a script's API calls bypass the
+// rate limiter and checkpoint carried on the real ctx, and aren't
+// subject to --rate. This is surfaced in --plugin-dir's
+// flag help rather than silently shipped. None is reserved so a future
+// version can give scripts a real way to observe cancellation.
+func (s *Script) Run(ctx context.Context, c *api.Client) error {
+	args := starlark.Tuple{starlark.None, clientValue(ctx, c)}
+	if _, err := starlark.Call(s.thread, s.globals["run"], args, nil); err != nil {
+		return fmt.Errorf("plugin %v failed: %w", s.Path, err)
+	}
+	return nil
+}
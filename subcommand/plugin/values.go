@@ -0,0 +1,109 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package plugin
+
+import (
+	"fmt"
+
+	"go.starlark.net/starlark"
+
+	"github.com/cu-library/almatoolkit/api"
+)
+
+// setValue exposes the fields of an api.Set a script needs to branch on
+// or report: its ID, name, type, and content.
+type setValue struct {
+	set api.Set
+}
+
+func (v *setValue) String() string       { return fmt.Sprintf("<set %v>", v.set.Name) }
+func (v *setValue) Type() string         { return "almatoolkit.set" }
+func (v *setValue) Freeze()              {}
+func (v *setValue) Truth() starlark.Bool { return starlark.True }
+func (v *setValue) Hash() (uint32, error) { return starlark.String(v.set.ID).Hash() }
+
+func (v *setValue) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "id":
+		return starlark.String(v.set.ID), nil
+	case "name":
+		return starlark.String(v.set.Name), nil
+	case "type":
+		return starlark.String(v.set.Type), nil
+	case "content":
+		return starlark.String(v.set.Content), nil
+	}
+	return nil, nil
+}
+
+func (v *setValue) AttrNames() []string {
+	return []string{"id", "name", "type", "content"}
+}
+
+// membersValue wraps the []api.Member a script receives from
+// client.set_members and passes, unexamined, to
+// client.item_members_user_requests.
+type membersValue struct {
+	members []api.Member
+}
+
+func (v *membersValue) String() string       { return fmt.Sprintf("<%v member(s)>", len(v.members)) }
+func (v *membersValue) Type() string         { return "almatoolkit.members" }
+func (v *membersValue) Freeze()              {}
+func (v *membersValue) Truth() starlark.Bool { return len(v.members) > 0 }
+func (v *membersValue) Hash() (uint32, error) {
+	return 0, fmt.Errorf("unhashable type: %v", v.Type())
+}
+
+// requestValue exposes the fields of an api.UserRequest a script needs
+// to branch on or report: its link, type, and subtype.
+type requestValue struct {
+	request api.UserRequest
+}
+
+func (v *requestValue) String() string        { return v.request.Link }
+func (v *requestValue) Type() string          { return "almatoolkit.request" }
+func (v *requestValue) Freeze()               {}
+func (v *requestValue) Truth() starlark.Bool  { return starlark.True }
+func (v *requestValue) Hash() (uint32, error) { return starlark.String(v.request.Link).Hash() }
+
+func (v *requestValue) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "link":
+		return starlark.String(v.request.Link), nil
+	case "type":
+		return starlark.String(v.request.Type), nil
+	case "subtype":
+		return starlark.String(v.request.SubType), nil
+	}
+	return nil, nil
+}
+
+func (v *requestValue) AttrNames() []string {
+	return []string{"link", "type", "subtype"}
+}
+
+// requestsFromList converts a Starlark list of requestValue back into
+// the []api.UserRequest client.user_requests_cancel needs.
+func requestsFromList(list *starlark.List) ([]api.UserRequest, error) {
+	requests := make([]api.UserRequest, 0, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		rv, ok := list.Index(i).(*requestValue)
+		if !ok {
+			return nil, fmt.Errorf("expected a list of requests, got %v at index %v", list.Index(i).Type(), i)
+		}
+		requests = append(requests, rv.request)
+	}
+	return requests, nil
+}
+
+func requestsToList(requests []api.UserRequest) *starlark.List {
+	values := make([]starlark.Value, len(requests))
+	for i, request := range requests {
+		values[i] = &requestValue{request: request}
+	}
+	return starlark.NewList(values)
+}
@@ -0,0 +1,47 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonWriter buffers rows and writes them as a single JSON array on Flush.
+type jsonWriter struct {
+	w       io.Writer
+	columns []string
+	rows    []map[string]string
+}
+
+func newJSONWriter(w io.Writer) Writer {
+	return &jsonWriter{w: w}
+}
+
+func (j *jsonWriter) Header(columns []string) error {
+	j.columns = columns
+	return nil
+}
+
+func (j *jsonWriter) Row(values []string) error {
+	row := make(map[string]string, len(j.columns))
+	for i, column := range j.columns {
+		if i < len(values) {
+			row[column] = values[i]
+		}
+	}
+	j.rows = append(j.rows, row)
+	return nil
+}
+
+func (j *jsonWriter) Flush() error {
+	enc := json.NewEncoder(j.w)
+	enc.SetIndent("", "  ")
+	if j.rows == nil {
+		j.rows = []map[string]string{}
+	}
+	return enc.Encode(j.rows)
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ndjsonWriter writes one JSON object per row, each on its own line, so
+// that a report can be streamed into tools like log aggregators without
+// waiting for the whole run to finish.
+type ndjsonWriter struct {
+	enc     *json.Encoder
+	columns []string
+}
+
+func newNDJSONWriter(w io.Writer) Writer {
+	return &ndjsonWriter{enc: json.NewEncoder(w)}
+}
+
+func (n *ndjsonWriter) Header(columns []string) error {
+	n.columns = columns
+	return nil
+}
+
+func (n *ndjsonWriter) Row(values []string) error {
+	row := make(map[string]string, len(n.columns))
+	for i, column := range n.columns {
+		if i < len(values) {
+			row[column] = values[i]
+		}
+	}
+	return n.enc.Encode(row)
+}
+
+func (n *ndjsonWriter) Flush() error {
+	return nil
+}
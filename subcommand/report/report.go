@@ -0,0 +1,87 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+// Package report provides output writers for subcommand results, so that
+// every subcommand can offer the same set of output formats without
+// reimplementing its own serialization.
+//
+// cancelrequests is wired up to report.New; requests, scanin, and
+// cleanupcallnumbers still write CSV directly with csv.NewWriter, and
+// main rejects any --output other than csv for them. They aren't part of this checkout, so that migration
+// is tracked here rather than attempted blind.
+package report
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Format identifies a supported report output format.
+type Format string
+
+// Supported output formats.
+const (
+	CSV    Format = "csv"
+	JSON   Format = "json"
+	NDJSON Format = "ndjson"
+	Table  Format = "table"
+)
+
+// Writer writes a report as a header row followed by data rows.
+//
+// Header must be called exactly once, before any call to Row. Flush must be
+// called once after the last Row, to give buffering formats (like JSON) a
+// chance to write their output.
+type Writer interface {
+	Header(columns []string) error
+	Row(values []string) error
+	Flush() error
+}
+
+type contextKey int
+
+const formatContextKey contextKey = 0
+
+// NewContext returns a copy of ctx which carries the given output format.
+func NewContext(ctx context.Context, format string) context.Context {
+	return context.WithValue(ctx, formatContextKey, Format(format))
+}
+
+// FormatFromContext returns the output format stored in ctx by NewContext,
+// or CSV if ctx carries none.
+func FormatFromContext(ctx context.Context) Format {
+	format, ok := ctx.Value(formatContextKey).(Format)
+	if !ok {
+		return CSV
+	}
+	return format
+}
+
+// ValidFormat reports whether format is one of the supported output formats.
+func ValidFormat(format string) bool {
+	switch Format(format) {
+	case CSV, JSON, NDJSON, Table:
+		return true
+	default:
+		return false
+	}
+}
+
+// New returns a Writer for the output format carried by ctx, writing to w.
+func New(ctx context.Context, w io.Writer) (Writer, error) {
+	switch format := FormatFromContext(ctx); format {
+	case CSV:
+		return newCSVWriter(w), nil
+	case JSON:
+		return newJSONWriter(w), nil
+	case NDJSON:
+		return newNDJSONWriter(w), nil
+	case Table:
+		return newTableWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unsupported output format %q", format)
+	}
+}
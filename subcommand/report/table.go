@@ -0,0 +1,36 @@
+// Copyright 2020 Carleton University Library.
+// All rights reserved.
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE.txt file.
+
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// tableWriter writes rows as an aligned, human readable table.
+type tableWriter struct {
+	w *tabwriter.Writer
+}
+
+func newTableWriter(w io.Writer) Writer {
+	return &tableWriter{w: tabwriter.NewWriter(w, 0, 2, 2, ' ', 0)}
+}
+
+func (t *tableWriter) Header(columns []string) error {
+	_, err := fmt.Fprintln(t.w, strings.Join(columns, "\t"))
+	return err
+}
+
+func (t *tableWriter) Row(values []string) error {
+	_, err := fmt.Fprintln(t.w, strings.Join(values, "\t"))
+	return err
+}
+
+func (t *tableWriter) Flush() error {
+	return t.w.Flush()
+}